@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// tryLockFile attempts to take a non-blocking exclusive fcntl lock on f,
+// returning immediately with an error if it's already held.
+func tryLockFile(f *os.File) error {
+	lock := syscall.Flock_t{
+		Type:   syscall.F_WRLCK,
+		Whence: 0,
+		Start:  0,
+		Len:    0,
+	}
+
+	return syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lock)
+}
+
+// unlockFile releases the fcntl lock taken by tryLockFile.
+func unlockFile(f *os.File) error {
+	lock := syscall.Flock_t{
+		Type:   syscall.F_UNLCK,
+		Whence: 0,
+		Start:  0,
+		Len:    0,
+	}
+
+	return syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lock)
+}
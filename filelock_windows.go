@@ -0,0 +1,59 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+)
+
+// tryLockFile attempts to take a non-blocking exclusive lock on f via
+// LockFileEx, returning immediately with an error if it's already held.
+func tryLockFile(f *os.File) error {
+	var overlapped syscall.Overlapped
+
+	r, _, err := procLockFileEx.Call(
+		f.Fd(),
+		uintptr(lockfileExclusiveLock|lockfileFailImmediately),
+		0,
+		^uintptr(0),
+		^uintptr(0),
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if r == 0 {
+		return err
+	}
+
+	return nil
+}
+
+// unlockFile releases the lock taken by tryLockFile.
+func unlockFile(f *os.File) error {
+	var overlapped syscall.Overlapped
+
+	r, _, err := procUnlockFileEx.Call(
+		f.Fd(),
+		0,
+		^uintptr(0),
+		^uintptr(0),
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if r == 0 {
+		return err
+	}
+
+	return nil
+}
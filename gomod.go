@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// modulePathMajorRegex matches a trailing major-version path element (/vN)
+// on a module path, mirroring the Go module system's path-major convention
+// (golang.org/x/mod's SplitPathVersion). N itself must still be checked
+// against 0 and 1, which don't get a path suffix.
+var modulePathMajorRegex = regexp.MustCompile(`^(.*)/v([1-9][0-9]*)$`)
+
+// findGoMod walks up from dir looking for a go.mod file, returning its path
+// and its module directive's path. ok is false if no go.mod is found.
+func findGoMod(dir string) (goModPath string, modulePath string, ok bool, err error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	for {
+		candidate := filepath.Join(abs, "go.mod")
+		if _, statErr := os.Stat(candidate); statErr == nil {
+			modulePath, err := readModulePath(candidate)
+			if err != nil {
+				return "", "", false, err
+			}
+			return candidate, modulePath, true, nil
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", "", false, nil
+		}
+		abs = parent
+	}
+}
+
+// readModulePath reads the "module <path>" directive from a go.mod file.
+func readModulePath(goModPath string) (string, error) {
+	f, err := os.Open(goModPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module")), nil
+		}
+	}
+
+	return "", fmt.Errorf("No module directive found in [%s]", goModPath)
+}
+
+// pathMajor returns a module path's major-version suffix ("/v2", "/v3", ...)
+// and whether it has one; v0 and v1 both share the unsuffixed path and
+// report ok=false.
+func pathMajor(modulePath string) (major string, ok bool) {
+	m := modulePathMajorRegex.FindStringSubmatch(modulePath)
+	if m == nil || m[2] == "1" {
+		return "", false
+	}
+	return "/v" + m[2], true
+}
+
+// pathMajorVersion returns the numeric major version implied by a module
+// path's /vN suffix, defaulting to 1 when the path has none.
+func pathMajorVersion(modulePath string) (int, error) {
+	major, ok := pathMajor(modulePath)
+	if !ok {
+		return 1, nil
+	}
+
+	return strconv.Atoi(strings.TrimPrefix(major, "/v"))
+}
+
+// seedVersion picks the version --init should seed when no managed version
+// is found: "<N>.0.0" when path is inside a module whose path carries a
+// /vN major-version suffix, or "1.0.0" otherwise (including when path isn't
+// inside a go.mod at all).
+func seedVersion(path string) string {
+	_, modulePath, ok, err := findGoMod(path)
+	if err != nil || !ok {
+		return "1.0.0"
+	}
+
+	major, err := pathMajorVersion(modulePath)
+	if err != nil {
+		return "1.0.0"
+	}
+
+	return fmt.Sprintf("%d.0.0", major)
+}
+
+// checkMajorBump ensures that bumping to newMajor matches the enclosing
+// go.mod's module path. If path isn't inside a go.mod, there's nothing to
+// check. If rewriteModule is set, the module path and its internal imports
+// are rewritten to match instead of the bump being rejected.
+func checkMajorBump(path string, newMajor int, rewriteModule bool) error {
+	_, modulePath, ok, err := findGoMod(path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	currentMajor, err := pathMajorVersion(modulePath)
+	if err != nil {
+		return err
+	}
+
+	if currentMajor == newMajor {
+		return nil
+	}
+
+	if !rewriteModule {
+		return fmt.Errorf("Module [%s] must have a /v%d path suffix to bump to MAJOR version %d; pass --rewrite-module to update go.mod and its internal imports", modulePath, newMajor, newMajor)
+	}
+
+	return rewriteModulePath(path, modulePath, newMajor)
+}
+
+// rewriteModulePath updates the enclosing go.mod's module directive and
+// every internal import of it from its current major-version suffix to
+// /v<newMajor>.
+func rewriteModulePath(path string, oldModulePath string, newMajor int) error {
+	goModPath, _, ok, err := findGoMod(path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("No go.mod found above [%s]", path)
+	}
+
+	prefix := oldModulePath
+	if _, hasMajor := pathMajor(oldModulePath); hasMajor {
+		prefix = oldModulePath[:strings.LastIndex(oldModulePath, "/v")]
+	}
+
+	newModulePath := prefix
+	if newMajor > 1 {
+		newModulePath = fmt.Sprintf("%s/v%d", prefix, newMajor)
+	}
+
+	if err := rewriteModuleDirective(goModPath, oldModulePath, newModulePath); err != nil {
+		return err
+	}
+
+	return rewriteInternalImports(filepath.Dir(goModPath), oldModulePath, newModulePath)
+}
+
+// rewriteModuleDirective rewrites the "module <oldModulePath>" line in
+// goModPath to "module <newModulePath>".
+func rewriteModuleDirective(goModPath, oldModulePath, newModulePath string) error {
+	data, err := ioutil.ReadFile(goModPath)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "module ") && strings.TrimSpace(strings.TrimPrefix(trimmed, "module")) == oldModulePath {
+			lines[i] = "module " + newModulePath
+			break
+		}
+	}
+
+	return writeFileAtomically(goModPath, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// rewriteInternalImports walks every .go file under root and rewrites any
+// import of oldModulePath, or one of its subpackages, to newModulePath.
+func rewriteInternalImports(root, oldModulePath, newModulePath string) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "vendor" || info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		rewritten := rewriteImportPaths(string(data), oldModulePath, newModulePath)
+		if rewritten == string(data) {
+			return nil
+		}
+
+		return writeFileAtomically(p, []byte(rewritten), info.Mode())
+	})
+}
+
+// rewriteImportPaths replaces oldModulePath import path occurrences,
+// including its subpackages, with newModulePath throughout src.
+func rewriteImportPaths(src, oldModulePath, newModulePath string) string {
+	src = strings.Replace(src, `"`+oldModulePath+`"`, `"`+newModulePath+`"`, -1)
+	src = strings.Replace(src, `"`+oldModulePath+`/`, `"`+newModulePath+`/`, -1)
+	return src
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathMajorVersion(t *testing.T) {
+	tests := []struct {
+		modulePath string
+		want       int
+	}{
+		{"example.com/foo", 1},
+		{"example.com/foo/v1", 1},
+		{"example.com/foo/v2", 2},
+		{"example.com/foo/v9", 9},
+		{"example.com/foo/v10", 10},
+		{"example.com/foo/v19", 19},
+		{"example.com/foo/v100", 100},
+	}
+
+	for _, test := range tests {
+		got, err := pathMajorVersion(test.modulePath)
+		if err != nil {
+			t.Errorf("pathMajorVersion(%q) returned error: %v", test.modulePath, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("pathMajorVersion(%q) = %d, want %d", test.modulePath, got, test.want)
+		}
+	}
+}
+
+func TestRewriteModulePath(t *testing.T) {
+	dir := t.TempDir()
+
+	goModPath := filepath.Join(dir, "go.mod")
+	if err := ioutil.WriteFile(goModPath, []byte("module example.com/foo/v2\n\ngo 1.16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgFile := filepath.Join(dir, "main.go")
+	src := "package main\n\nimport \"example.com/foo/v2/sub\"\n\nvar _ = sub.X\n"
+	if err := ioutil.WriteFile(pkgFile, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rewriteModulePath(dir, "example.com/foo/v2", 3); err != nil {
+		t.Fatalf("rewriteModulePath returned error: %v", err)
+	}
+
+	gotMod, err := ioutil.ReadFile(goModPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(gotMod), "module example.com/foo/v3") {
+		t.Errorf("go.mod does not contain the rewritten module directive, got:\n%s", gotMod)
+	}
+
+	gotPkg, err := ioutil.ReadFile(pkgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(gotPkg), `"example.com/foo/v3/sub"`) {
+		t.Errorf("main.go does not contain the rewritten import, got:\n%s", gotPkg)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockedFile holds an exclusive OS-level lock on a version.go.lock sentinel
+// file next to a generated version.go.
+type lockedFile struct {
+	f *os.File
+}
+
+// lockVersionFile takes an exclusive lock on <dir>/version.go.lock, creating
+// it if necessary, retrying until it succeeds or timeout elapses. A
+// non-positive timeout waits forever.
+func lockVersionFile(dir string, timeout time.Duration) (*lockedFile, error) {
+	lockPath := filepath.Join(dir, "version.go.lock")
+
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		err := tryLockFile(f)
+		if err == nil {
+			return &lockedFile{f: f}, nil
+		}
+
+		if timeout > 0 && time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for lock on [%s]: %v", timeout, lockPath, err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Unlock releases the lock and closes the underlying file.
+func (l *lockedFile) Unlock() error {
+	unlockErr := unlockFile(l.f)
+	closeErr := l.f.Close()
+	if unlockErr != nil {
+		return unlockErr
+	}
+
+	return closeErr
+}
+
+// writeFileAtomically writes data to path by writing to a temp file in the
+// same directory and renaming it into place, so a reader racing the write
+// (or a crash mid-write) never observes a partial version.go.
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockVersionFileReacquire(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := lockVersionFile(dir, time.Second)
+	if err != nil {
+		t.Fatalf("lockVersionFile returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "version.go.lock")); err != nil {
+		t.Errorf("lock file was not created: %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+
+	lock, err = lockVersionFile(dir, time.Second)
+	if err != nil {
+		t.Fatalf("lockVersionFile after Unlock returned error: %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+}
+
+// TestLockVersionFileTimesOut re-execs this test binary as a helper process
+// that holds the lock, then verifies a concurrent lockVersionFile call in
+// this process gives up once its timeout elapses.
+func TestLockVersionFileTimesOut(t *testing.T) {
+	if os.Getenv("GIDDYUP_LOCK_HELPER") == "1" {
+		lock, err := lockVersionFile(os.Getenv("GIDDYUP_LOCK_DIR"), 0)
+		if err != nil {
+			os.Exit(1)
+		}
+		defer lock.Unlock()
+		time.Sleep(2 * time.Second)
+		return
+	}
+
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=TestLockVersionFileTimesOut")
+	cmd.Env = append(os.Environ(), "GIDDYUP_LOCK_HELPER=1", "GIDDYUP_LOCK_DIR="+dir)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	time.Sleep(300 * time.Millisecond)
+
+	if _, err := lockVersionFile(dir, 200*time.Millisecond); err == nil {
+		t.Error("lockVersionFile succeeded while the helper process held the lock")
+	}
+}
+
+func TestWriteFileAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "version.go")
+
+	if err := writeFileAtomically(path, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("writeFileAtomically returned error: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading written file: %v", err)
+	}
+	if string(data) != "package main\n" {
+		t.Errorf("written content = %q, want %q", data, "package main\n")
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "version.go" {
+			t.Errorf("leftover file in directory after atomic write: %s", entry.Name())
+		}
+	}
+}
@@ -16,19 +16,25 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	app            = kingpin.New("giddyup", "A go generate tool to increment an application's version.")
-	variable       = kingpin.Flag("variable", "Name of the version variable.").Default("VERSION").String()
-	mode           = kingpin.Flag("mode", "Increment mode (MAJOR, MINOR, PATCH)").Short('m').Default("PATCH").Enum("MAJOR", "MINOR", "PATCH")
+	variableFlags  = kingpin.Flag("variable", "Identifier to emit, as name[:kind] where kind is const (default) or var (overridable at link time via -ldflags). Repeatable. name may be VERSION, MAJOR, MINOR, PATCH, PRERELEASE, BUILD, COMMIT, BUILD_DATE, or any custom name to alias VERSION.").Default("VERSION").Strings()
+	mode           = kingpin.Flag("mode", "Increment mode (MAJOR, MINOR, PATCH, PRERELEASE, BUILD)").Short('m').Default("PATCH").Enum("MAJOR", "MINOR", "PATCH", "PRERELEASE", "BUILD")
+	prereleaseID   = kingpin.Flag("prerelease-id", "Pre-release identifier used when entering PRERELEASE mode from a release version").Default("rc").String()
 	paths          = kingpin.Arg("paths", "directories or files").Strings()
 	currentVersion = kingpin.Flag("toolVersion", "Only prints the current version of the tool without incrementing for the next release").Short('t').Default("false").Bool()
 	verbose        = kingpin.Flag("verbose", "Verbose output (prints current and next dev versions)").Short('v').Default("false").Bool()
 	lazyInit       = kingpin.Flag("init", "Initialize version to 1.0.0 if no managed version found)").Short('i').Default("false").Bool()
+	vcs            = kingpin.Flag("vcs", "Source of truth for the current version (go reads version.go, git reads tags reachable from HEAD)").Default("go").Enum("go", "git")
+	source         = kingpin.Flag("source", "Where the current/next version is read from and written to: go (version.go, default) or file:<path> (a plain-text version file, e.g. file:VERSION)").Default("go").String()
+	tagRelease     = kingpin.Flag("tag", "With --vcs=git, create an annotated git tag for the next version").Default("false").Bool()
+	pushTag        = kingpin.Flag("push", "With --vcs=git and --tag, push the created tag to its remote").Default("false").Bool()
+	lockTimeout    = kingpin.Flag("timeout", "How long to wait for another giddyup run's version.go.lock before failing (0 waits forever)").Default("30s").Duration()
+	rewriteModule  = kingpin.Flag("rewrite-module", "When bumping MAJOR, update the enclosing go.mod module path and internal imports to match instead of refusing the bump").Default("false").Bool()
 )
 
 type errWriter struct {
@@ -43,10 +49,22 @@ func (ew *errWriter) writeString(value string) {
 	_, ew.err = ew.b.WriteString(value)
 }
 
+// primaryVariable is the identifier giddyup reads the current version from
+// and writes the bumped version back to: the name of the first --variable
+// declaration.
+var primaryVariable string
+
 func main() {
 	kingpin.Version(VERSION)
 	kingpin.Parse()
 
+	variables, err := parseVariableFlags(*variableFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing --variable: %v", err)
+		return
+	}
+	primaryVariable = variables[0].Name
+
 	inputPaths := *paths
 	if len(inputPaths) == 0 {
 		// Default: process whole package in current directory.
@@ -54,18 +72,38 @@ func main() {
 	}
 
 	if *currentVersion {
-		if err := printCurrentVersion(*variable, inputPaths); err != nil {
+		if err := printCurrentVersion(inputPaths, *vcs, *source); err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting current version: %v", err)
 		}
 	} else {
-		if err := run(*variable, inputPaths, *mode, *lazyInit); err != nil {
+		if err := run(variables, inputPaths, *mode, *prereleaseID, *vcs, *source, *lazyInit, *tagRelease, *pushTag, *lockTimeout, *rewriteModule); err != nil {
 			fmt.Fprintf(os.Stderr, "Error generating version: %v", err)
 		}
 	}
 }
 
-func printCurrentVersion(variable string, inputPaths []string) error {
+func printCurrentVersion(inputPaths []string, vcs string, source string) error {
+	sourceKind, sourcePath := splitSource(source)
+
 	for _, path := range inputPaths {
+		if vcs == "git" {
+			version, err := gitToolVersion(filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s", version)
+			continue
+		}
+
+		if sourceKind == "file" {
+			version, err := getCurrentVersionFromFile(filepath.Join(filepath.Dir(path), sourcePath), false)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s", version)
+			continue
+		}
+
 		version, err := getCurrentVersion(path, false)
 		if err != nil {
 			return err
@@ -76,44 +114,153 @@ func printCurrentVersion(variable string, inputPaths []string) error {
 	return nil
 }
 
-func run(variable string, inputPaths []string, mode string, lazyInit bool) error {
+// gitToolVersion returns the version that --vcs=git considers "current":
+// the highest reachable release tag verbatim when HEAD is that tag, or a
+// Go-module-style pseudo-version derived from it when HEAD is ahead.
+func gitToolVersion(path string) (string, error) {
+	_, tagged, ok, err := highestGitTag(path)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("No version tag found in git repository at [%s]", path)
+	}
+
+	atTag, err := isHeadAtTag(path, gitTagPrefix+tagged.String())
+	if err != nil {
+		return "", err
+	}
+	if atTag {
+		return gitTagPrefix + tagged.String(), nil
+	}
+
+	sha, committed, err := headCommit(path)
+	if err != nil {
+		return "", err
+	}
+
+	return pseudoVersion(tagged, committed, sha), nil
+}
+
+func run(variables []versionVariable, inputPaths []string, mode string, prereleaseID string, vcs string, source string, lazyInit bool, doTag bool, doPush bool, lockTimeout time.Duration, rewriteModule bool) error {
 	for _, path := range inputPaths {
-		version, err := getCurrentVersion(path, lazyInit)
+		dir := filepath.Dir(path)
+
+		lock, err := lockVersionFile(dir, lockTimeout)
 		if err != nil {
 			return err
 		}
 
-		if *verbose {
-			fmt.Printf("Current version is [%s]\n", version)
+		if err := generateOne(path, dir, variables, mode, prereleaseID, vcs, source, lazyInit, doTag, doPush, rewriteModule); err != nil {
+			lock.Unlock()
+			return err
 		}
 
-		nextDevVersion, err := getNextVersion(version, mode)
-		if err != nil {
+		if err := lock.Unlock(); err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// generateOne regenerates version.go for a single path. It runs with an
+// exclusive lock held on dir's version.go.lock so that the current version
+// is re-read and recomputed under the lock, keeping concurrent `go
+// generate` invocations from racing each other's bump.
+func generateOne(path string, dir string, variables []versionVariable, mode string, prereleaseID string, vcs string, source string, lazyInit bool, doTag bool, doPush bool, rewriteModule bool) error {
+	sourceKind, sourcePath := splitSource(source)
+
+	var version string
+	var err error
+	switch {
+	case vcs == "git":
+		version, err = getCurrentVersionFromGit(dir, lazyInit)
+	case sourceKind == "file":
+		version, err = getCurrentVersionFromFile(filepath.Join(dir, sourcePath), lazyInit)
+	default:
+		version, err = getCurrentVersion(path, lazyInit)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *verbose {
+		fmt.Printf("Current version is [%s]\n", version)
+	}
+
+	nextDevVersion, err := getNextVersion(version, mode, prereleaseID)
+	if err != nil {
+		return err
+	}
+
+	parsedNextVersion, err := parseSemVer(nextDevVersion)
+	if err != nil {
+		return err
+	}
 
-		if *verbose {
-			fmt.Printf("Next dev version is [%s]\n", nextDevVersion)
+	if mode == "MAJOR" {
+		if err := checkMajorBump(dir, parsedNextVersion.Major, rewriteModule); err != nil {
+			return err
 		}
+	}
+
+	if *verbose {
+		fmt.Printf("Next dev version is [%s]\n", nextDevVersion)
+	}
 
-		var buffer bytes.Buffer
-		if err := writeHeader(&buffer, variable); err != nil {
+	if sourceKind == "file" {
+		if err := writeVersionFile(filepath.Join(dir, sourcePath), nextDevVersion); err != nil {
 			return err
 		}
-		pkg := parsePackage(path)
+	}
 
-		err = generateContent(pkg, nextDevVersion, variable, &buffer)
+	var commit string
+	if vcs == "git" {
+		sha, _, err := headCommit(dir)
 		if err != nil {
 			return err
 		}
+		commit = shortSHA(sha)
+	}
 
-		// Write to file.
-		output := fmt.Sprintf("%s/version.go", filepath.Dir(path))
+	// Preserve the historical default of also emitting COMMIT when
+	// --vcs=git, as long as --variable hasn't been customized away from
+	// its own default.
+	if vcs == "git" && len(variables) == 1 && variables[0] == (versionVariable{Name: "VERSION", Kind: "const"}) {
+		variables = append(variables, versionVariable{Name: "COMMIT", Kind: "const"})
+	}
 
-		err = ioutil.WriteFile(output, buffer.Bytes(), 0644)
-		if err != nil {
+	var buffer bytes.Buffer
+	if err := writeHeader(&buffer, primaryVariable); err != nil {
+		return err
+	}
+	pkg := parsePackage(path)
+
+	err = generateContent(pkg, parsedNextVersion, nextDevVersion, commit, variables, &buffer)
+	if err != nil {
+		return err
+	}
+
+	// Write to file, atomically so a concurrent reader never observes a
+	// partial version.go.
+	output := fmt.Sprintf("%s/version.go", dir)
+
+	err = writeFileAtomically(output, buffer.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	if vcs == "git" && doTag {
+		if err := createGitTag(dir, nextDevVersion); err != nil {
 			return err
 		}
+
+		if doPush {
+			if err := pushGitTag(dir, nextDevVersion); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -135,11 +282,12 @@ func getCurrentVersion(path string, lazyInit bool) (string, error) {
 	f, err := parser.ParseFile(fset, versionFilePath, nil, 0)
 	if err != nil {
 		if lazyInit {
+			seed := seedVersion(path)
 			if *verbose {
-				fmt.Printf("Version file not found at [%s], initializing version to [1.0.0]\n", versionFilePath)
+				fmt.Printf("Version file not found at [%s], initializing version to [%s]\n", versionFilePath, seed)
 			}
 
-			return "1.0.0", nil
+			return seed, nil
 		}
 		return "", err
 	}
@@ -151,7 +299,7 @@ func getCurrentVersion(path string, lazyInit bool) (string, error) {
 				for _, spec := range decl.Specs {
 					if valueSpec, ok := spec.(*ast.ValueSpec); ok {
 						for _, name := range valueSpec.Names {
-							if name.String() == *variable {
+							if name.String() == primaryVariable {
 								for _, value := range valueSpec.Values {
 									if basicLiteral, ok := value.(*ast.BasicLit); ok {
 										return strings.Trim(basicLiteral.Value, "\""), nil
@@ -165,42 +313,46 @@ func getCurrentVersion(path string, lazyInit bool) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("Could not find version constant [%s] in file [%s]", *variable, versionFilePath)
+	return "", fmt.Errorf("Could not find version constant [%s] in file [%s]", primaryVariable, versionFilePath)
 }
 
-func getNextVersion(version string, mode string) (string, error) {
-	versionRegEx := regexp.MustCompile("\\A(\\d)+\\.(\\d)\\.(\\d)+\\z")
-
-	if versionRegEx.MatchString(version) {
-		matches := versionRegEx.FindAllStringSubmatch(version, -1)[0]
-		majorVersion, err := strconv.Atoi(matches[1])
-		if err != nil {
-			return "", fmt.Errorf("Version format should be [number.number.number] but was [%s]: [%v]", version, err)
-		}
-
-		minorVersion, err := strconv.Atoi(matches[2])
-		if err != nil {
-			return "", fmt.Errorf("Version format should be [number.number.number] but was [%s]: [%v]", version, err)
-		}
-
-		patchVersion, err := strconv.Atoi(matches[3])
-		if err != nil {
-			return "", fmt.Errorf("Version format should be [number.number.number] but was [%s]: [%v]", version, err)
-		}
-
-		switch mode {
-		case "PATCH":
-			patchVersion = patchVersion + 1
-		case "MINOR":
-			minorVersion = minorVersion + 1
-		case "MAJOR":
-			majorVersion = majorVersion + 1
-		}
+// getNextVersion parses version as a SemVer 2.0.0 string and returns the
+// next version for the given mode. MAJOR, MINOR and PATCH bump the
+// corresponding core identifier and clear any pre-release/build metadata.
+// PRERELEASE increments a trailing dot-numeric pre-release identifier (e.g.
+// "-rc.3" -> "-rc.4") or, if the version has no pre-release tag yet, bumps
+// the patch version and starts one at "-<prereleaseID>.1". BUILD appends or
+// increments a trailing "+build.N" metadata segment without touching the
+// core version or pre-release tag.
+func getNextVersion(version string, mode string, prereleaseID string) (string, error) {
+	v, err := parseSemVer(version)
+	if err != nil {
+		return "", err
+	}
 
-		return fmt.Sprintf("%d.%d.%d", majorVersion, minorVersion, patchVersion), nil
-	} else {
-		return "", fmt.Errorf("Version format should be [number.number.number] but was [%s]", version)
+	switch mode {
+	case "PATCH":
+		v.Patch++
+		v.Prerelease = nil
+		v.Build = nil
+	case "MINOR":
+		v.Minor++
+		v.Patch = 0
+		v.Prerelease = nil
+		v.Build = nil
+	case "MAJOR":
+		v.Major++
+		v.Minor = 0
+		v.Patch = 0
+		v.Prerelease = nil
+		v.Build = nil
+	case "PRERELEASE":
+		v = bumpPrerelease(v, prereleaseID)
+	case "BUILD":
+		v = bumpBuild(v)
 	}
+
+	return v.String(), nil
 }
 
 // writeHeader writes the header of the file (code generation warning as well as the go:generate line)
@@ -211,12 +363,54 @@ func writeHeader(buffer *bytes.Buffer, variable string) error {
 	return ew.err
 }
 
-func generateContent(pkg string, version string, variable string, buffer *bytes.Buffer) error {
-	buffer.WriteString(fmt.Sprintf("package %s\n\nconst (\n\t%s = \"%s\"\n)\n", pkg, variable, version))
+// generateContent renders the generated version.go body: a package clause
+// followed by a const block and/or a var block, one line per declared
+// variable, holding its derived value (see derivedValues). Grouping by kind
+// and omitting empty blocks keeps the output gofmt-clean.
+func generateContent(pkg string, version semVer, versionStr string, commit string, variables []versionVariable, buffer *bytes.Buffer) error {
+	values := derivedValues(version, versionStr, commit)
+
+	var consts, vars []versionVariable
+	for _, v := range variables {
+		if v.Kind == "var" {
+			vars = append(vars, v)
+		} else {
+			consts = append(consts, v)
+		}
+	}
+
+	buffer.WriteString(fmt.Sprintf("package %s\n", pkg))
+
+	if len(consts) > 0 {
+		buffer.WriteString("\nconst (\n")
+		writeVariableBlock(buffer, consts, values)
+		buffer.WriteString(")\n")
+	}
+
+	if len(vars) > 0 {
+		buffer.WriteString("\nvar (\n")
+		writeVariableBlock(buffer, vars, values)
+		buffer.WriteString(")\n")
+	}
 
 	return nil
 }
 
+// writeVariableBlock writes one gofmt-style declaration per variable, with
+// the `=` signs aligned to the widest name in the block.
+func writeVariableBlock(buffer *bytes.Buffer, variables []versionVariable, values map[string]string) {
+	width := 0
+	for _, v := range variables {
+		if len(v.Name) > width {
+			width = len(v.Name)
+		}
+	}
+
+	for _, v := range variables {
+		buffer.WriteString(fmt.Sprintf("\t%-*s = %q\n", width, v.Name, valueForVariable(v.Name, values)))
+	}
+}
+
 // parsePackage analyzes the single package constructed from the named files.
 // If text is non-nil, it is a string to be used instead of the content of the file,
 // to be used for testing. parsePackage exits if there is an error.
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"testing"
+)
+
+func TestGenerateContentIsGofmtClean(t *testing.T) {
+	version, err := parseSemVer("1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variables := []versionVariable{
+		{Name: "VERSION", Kind: "const"},
+		{Name: "MAJOR", Kind: "var"},
+		{Name: "COMMIT", Kind: "var"},
+	}
+
+	var buffer bytes.Buffer
+	if err := generateContent("main", version, "1.2.3", "abc123", variables, &buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	formatted, err := format.Source(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("generated content is not valid Go: %v\n%s", err, buffer.String())
+	}
+
+	if !bytes.Equal(formatted, buffer.Bytes()) {
+		t.Errorf("generateContent output is not gofmt-clean, got:\n%s\nwant:\n%s", buffer.String(), formatted)
+	}
+}
+
+func TestGenerateContentStable(t *testing.T) {
+	version, err := parseSemVer("1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variables := []versionVariable{{Name: "VERSION", Kind: "const"}}
+
+	var first, second bytes.Buffer
+	if err := generateContent("main", version, "1.0.0", "", variables, &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateContent("main", version, "1.0.0", "", variables, &second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.String() != second.String() {
+		t.Errorf("repeated generateContent runs produced different output:\n%s\nvs\n%s", first.String(), second.String())
+	}
+}
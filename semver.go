@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semVer is a parsed representation of a SemVer 2.0.0 version string, split
+// into its core major.minor.patch triple plus the optional pre-release and
+// build metadata identifier lists (e.g. "rc" "1" for "-rc.1").
+type semVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      []string
+}
+
+// parseSemVer parses version according to the SemVer 2.0.0 spec
+// (https://semver.org): a core "major.minor.patch" triple where none of the
+// numeric identifiers may carry leading zeros, followed by an optional
+// "-<prerelease>" and/or "+<build>" metadata suffix.
+func parseSemVer(version string) (semVer, error) {
+	rest := version
+
+	var build string
+	hasBuild := false
+	if idx := strings.Index(rest, "+"); idx >= 0 {
+		build = rest[idx+1:]
+		hasBuild = true
+		rest = rest[:idx]
+	}
+
+	var prerelease string
+	hasPrerelease := false
+	if idx := strings.Index(rest, "-"); idx >= 0 {
+		prerelease = rest[idx+1:]
+		hasPrerelease = true
+		rest = rest[:idx]
+	}
+
+	core := strings.SplitN(rest, ".", 3)
+	if len(core) != 3 {
+		return semVer{}, fmt.Errorf("Version format should be [number.number.number] but was [%s]", version)
+	}
+
+	major, err := parseNumericIdentifier(core[0])
+	if err != nil {
+		return semVer{}, fmt.Errorf("Version format should be [number.number.number] but was [%s]: [%v]", version, err)
+	}
+
+	minor, err := parseNumericIdentifier(core[1])
+	if err != nil {
+		return semVer{}, fmt.Errorf("Version format should be [number.number.number] but was [%s]: [%v]", version, err)
+	}
+
+	patch, err := parseNumericIdentifier(core[2])
+	if err != nil {
+		return semVer{}, fmt.Errorf("Version format should be [number.number.number] but was [%s]: [%v]", version, err)
+	}
+
+	sv := semVer{Major: major, Minor: minor, Patch: patch}
+
+	if hasPrerelease {
+		if prerelease == "" {
+			return semVer{}, fmt.Errorf("Invalid pre-release identifier [%s] in version [%s]", prerelease, version)
+		}
+		ids := strings.Split(prerelease, ".")
+		for _, id := range ids {
+			if !isValidIdentifier(id) {
+				return semVer{}, fmt.Errorf("Invalid pre-release identifier [%s] in version [%s]", id, version)
+			}
+		}
+		sv.Prerelease = ids
+	}
+
+	if hasBuild {
+		if build == "" {
+			return semVer{}, fmt.Errorf("Invalid build identifier [%s] in version [%s]", build, version)
+		}
+		ids := strings.Split(build, ".")
+		for _, id := range ids {
+			if !isValidIdentifier(id) {
+				return semVer{}, fmt.Errorf("Invalid build identifier [%s] in version [%s]", id, version)
+			}
+		}
+		sv.Build = ids
+	}
+
+	return sv, nil
+}
+
+// parseNumericIdentifier parses a core version identifier, rejecting
+// anything that isn't all-digit or that carries a leading zero (per the
+// SemVer 2.0.0 spec).
+func parseNumericIdentifier(s string) (int, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("identifier [%s] must not have leading zeros", s)
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("identifier [%s] must not be negative", s)
+	}
+
+	return n, nil
+}
+
+// isValidIdentifier reports whether s is a valid SemVer pre-release or build
+// identifier: a non-empty run of ASCII alphanumerics and hyphens, with
+// numeric-only identifiers additionally forbidden from leading zeros.
+func isValidIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	numeric := true
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+			numeric = false
+		default:
+			return false
+		}
+	}
+
+	if numeric && len(s) > 1 && s[0] == '0' {
+		return false
+	}
+
+	return true
+}
+
+// String renders the semVer back to its canonical SemVer 2.0.0 string.
+func (v semVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+
+	return s
+}
+
+// compareIdentifiers compares two pre-release identifier lists per the
+// SemVer 2.0.0 precedence rules (build metadata is never considered).
+func compareIdentifiers(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, aIsNum := asNumericIdentifier(a[i])
+		nb, bIsNum := asNumericIdentifier(b[i])
+
+		switch {
+		case aIsNum && bIsNum:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case aIsNum && !bIsNum:
+			return -1
+		case !aIsNum && bIsNum:
+			return 1
+		default:
+			if a[i] != b[i] {
+				if a[i] < b[i] {
+					return -1
+				}
+				return 1
+			}
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+func asNumericIdentifier(s string) (int, bool) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// compare returns -1, 0 or 1 depending on whether v orders before, the same
+// as, or after other, following SemVer 2.0.0 precedence (a version with a
+// pre-release has lower precedence than its associated normal version).
+func (v semVer) compare(other semVer) int {
+	if v.Major != other.Major {
+		if v.Major < other.Major {
+			return -1
+		}
+		return 1
+	}
+	if v.Minor != other.Minor {
+		if v.Minor < other.Minor {
+			return -1
+		}
+		return 1
+	}
+	if v.Patch != other.Patch {
+		if v.Patch < other.Patch {
+			return -1
+		}
+		return 1
+	}
+
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	default:
+		return compareIdentifiers(v.Prerelease, other.Prerelease)
+	}
+}
+
+// bumpPrerelease applies PRERELEASE mode semantics: if the version already
+// carries a dot-numeric pre-release tail (e.g. "-rc.3"), the trailing
+// numeric identifier is incremented in place; otherwise the patch version is
+// bumped and a new "<id>.1" pre-release is started.
+func bumpPrerelease(v semVer, prereleaseID string) semVer {
+	if n := len(v.Prerelease); n >= 2 {
+		if last, ok := asNumericIdentifier(v.Prerelease[n-1]); ok {
+			next := make([]string, n)
+			copy(next, v.Prerelease)
+			next[n-1] = strconv.Itoa(last + 1)
+			v.Prerelease = next
+			v.Build = nil
+			return v
+		}
+	}
+
+	v.Patch++
+	v.Prerelease = []string{prereleaseID, "1"}
+	v.Build = nil
+	return v
+}
+
+// bumpBuild applies BUILD mode semantics: append or increment a trailing
+// "build.N" build metadata identifier without touching the core version or
+// any pre-release tag.
+func bumpBuild(v semVer) semVer {
+	if n := len(v.Build); n >= 2 && v.Build[n-2] == "build" {
+		if last, ok := asNumericIdentifier(v.Build[n-1]); ok {
+			next := make([]string, n)
+			copy(next, v.Build)
+			next[n-1] = strconv.Itoa(last + 1)
+			v.Build = next
+			return v
+		}
+	}
+
+	v.Build = append(append([]string{}, v.Build...), "build", "1")
+	return v
+}
@@ -0,0 +1,111 @@
+package main
+
+import "testing"
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		version string
+		want    semVer
+	}{
+		{"1.2.3", semVer{Major: 1, Minor: 2, Patch: 3}},
+		{"1.2.10", semVer{Major: 1, Minor: 2, Patch: 10}},
+		{"1.2.3-rc.1", semVer{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"rc", "1"}}},
+		{"1.2.3-alpha.2+build.7", semVer{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"alpha", "2"}, Build: []string{"build", "7"}}},
+		{"1.2.3+build.7", semVer{Major: 1, Minor: 2, Patch: 3, Build: []string{"build", "7"}}},
+	}
+
+	for _, test := range tests {
+		got, err := parseSemVer(test.version)
+		if err != nil {
+			t.Errorf("parseSemVer(%q) returned error: %v", test.version, err)
+			continue
+		}
+		if got.String() != test.want.String() {
+			t.Errorf("parseSemVer(%q) = %+v, want %+v", test.version, got, test.want)
+		}
+	}
+}
+
+func TestParseSemVerInvalid(t *testing.T) {
+	tests := []string{
+		"1.2",
+		"1.02.3",
+		"1.2.3-",
+		"1.2.3-01",
+		"v1.2.3",
+		"1.2.3-rc..1",
+	}
+
+	for _, version := range tests {
+		if _, err := parseSemVer(version); err == nil {
+			t.Errorf("parseSemVer(%q) expected error, got none", version)
+		}
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "2.0.0", -1},
+		{"2.0.0", "1.0.0", 1},
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0", "1.0.0-alpha", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.2", -1},
+	}
+
+	for _, test := range tests {
+		a, err := parseSemVer(test.a)
+		if err != nil {
+			t.Fatalf("parseSemVer(%q) returned error: %v", test.a, err)
+		}
+		b, err := parseSemVer(test.b)
+		if err != nil {
+			t.Fatalf("parseSemVer(%q) returned error: %v", test.b, err)
+		}
+
+		if got := a.compare(b); got != test.want {
+			t.Errorf("%q.compare(%q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestGetNextVersion(t *testing.T) {
+	tests := []struct {
+		version      string
+		mode         string
+		prereleaseID string
+		want         string
+	}{
+		{"1.2.3", "PATCH", "rc", "1.2.4"},
+		{"1.2.3", "MINOR", "rc", "1.3.0"},
+		{"1.2.3", "MAJOR", "rc", "2.0.0"},
+		{"1.2.3", "PRERELEASE", "rc", "1.2.4-rc.1"},
+		{"1.2.3-rc.3", "PRERELEASE", "rc", "1.2.3-rc.4"},
+		{"1.2.3", "BUILD", "rc", "1.2.3+build.1"},
+		{"1.2.3+build.1", "BUILD", "rc", "1.2.3+build.2"},
+	}
+
+	for _, test := range tests {
+		got, err := getNextVersion(test.version, test.mode, test.prereleaseID)
+		if err != nil {
+			t.Errorf("getNextVersion(%q, %q, %q) returned error: %v", test.version, test.mode, test.prereleaseID, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("getNextVersion(%q, %q, %q) = %q, want %q", test.version, test.mode, test.prereleaseID, got, test.want)
+		}
+	}
+}
+
+func TestBumpBuildStartsOverWithoutBuildPrefix(t *testing.T) {
+	v := semVer{Major: 1, Minor: 0, Patch: 0, Build: []string{"7"}}
+	got := bumpBuild(v)
+	if got.String() != "1.0.0+7.build.1" {
+		t.Errorf("bumpBuild(%+v) = %q, want %q", v, got.String(), "1.0.0+7.build.1")
+	}
+}
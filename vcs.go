@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitTagPrefix is the prefix expected on version tags managed by giddyup.
+const gitTagPrefix = "v"
+
+// highestGitTag returns the highest vX.Y.Z[-pre] tag reachable from HEAD in
+// the git repository at path, along with its parsed semver (without the
+// leading "v"). ok is false if no matching tag is reachable.
+func highestGitTag(path string) (tag string, version semVer, ok bool, err error) {
+	out, err := runGit(path, "tag", "--merged", "HEAD")
+	if err != nil {
+		return "", semVer{}, false, err
+	}
+
+	var bestTag string
+	var best semVer
+	found := false
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, gitTagPrefix) {
+			continue
+		}
+
+		v, err := parseSemVer(strings.TrimPrefix(line, gitTagPrefix))
+		if err != nil {
+			continue
+		}
+
+		if !found || v.compare(best) > 0 {
+			best = v
+			bestTag = line
+			found = true
+		}
+	}
+
+	return bestTag, best, found, nil
+}
+
+// getCurrentVersionFromGit resolves the current version from the highest
+// vX.Y.Z[-pre] tag reachable from HEAD, falling back to seedVersion's
+// "<N>.0.0" seed when lazyInit is set and no such tag exists.
+func getCurrentVersionFromGit(path string, lazyInit bool) (string, error) {
+	_, version, ok, err := highestGitTag(path)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		if lazyInit {
+			seed := seedVersion(path)
+			if *verbose {
+				fmt.Printf("No version tag found in git repository at [%s], initializing version to [%s]\n", path, seed)
+			}
+			return seed, nil
+		}
+		return "", fmt.Errorf("No version tag found in git repository at [%s]", path)
+	}
+
+	return version.String(), nil
+}
+
+// headCommit returns the full SHA of HEAD and its committer date.
+func headCommit(path string) (sha string, committed time.Time, err error) {
+	sha, err = runGit(path, "rev-parse", "HEAD")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	sha = strings.TrimSpace(sha)
+
+	out, err := runGit(path, "log", "-1", "--format=%cI", sha)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	committed, err = time.Parse(time.RFC3339, strings.TrimSpace(out))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return sha, committed, nil
+}
+
+// shortSHA returns the 12-character commit hash prefix used in pseudo-versions.
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// pseudoVersion builds a Go-module-style pseudo-version
+// (vX.Y.(Z+1)-0.<timestamp>-<sha>) for a commit ahead of tagged, the highest
+// reachable release tag.
+func pseudoVersion(tagged semVer, committed time.Time, sha string) string {
+	next := tagged
+	next.Patch++
+	next.Prerelease = nil
+	next.Build = nil
+
+	timestamp := committed.UTC().Format("20060102150405")
+	return fmt.Sprintf("v%s-0.%s-%s", next.String(), timestamp, shortSHA(sha))
+}
+
+// isHeadAtTag reports whether HEAD resolves to the same commit as tag.
+func isHeadAtTag(path, tag string) (bool, error) {
+	headSHA, err := runGit(path, "rev-parse", "HEAD")
+	if err != nil {
+		return false, err
+	}
+
+	tagSHA, err := runGit(path, "rev-list", "-n", "1", tag)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(headSHA) == strings.TrimSpace(tagSHA), nil
+}
+
+// createGitTag creates an annotated tag for version in the repository at path.
+func createGitTag(path, version string) error {
+	tag := gitTagPrefix + version
+	_, err := runGit(path, "tag", "-a", tag, "-m", fmt.Sprintf("Release %s", version))
+	return err
+}
+
+// pushGitTag pushes tag for version to the default remote.
+func pushGitTag(path, version string) error {
+	tag := gitTagPrefix + version
+	_, err := runGit(path, "push", "origin", tag)
+	return err
+}
+
+// runGit runs git with args in the repository at path and returns its
+// trimmed stdout.
+func runGit(path string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = path
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %v", strings.Join(args, " "), err)
+	}
+
+	return string(out), nil
+}
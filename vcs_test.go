@@ -0,0 +1,177 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	run("commit", "--allow-empty", "-q", "-m", "initial")
+
+	return dir
+}
+
+func commitAndTag(t *testing.T, dir, tag string) {
+	t.Helper()
+	cmd := exec.Command("git", "commit", "--allow-empty", "-q", "-m", tag)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, out)
+	}
+	cmd = exec.Command("git", "tag", "-a", tag, "-m", tag)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git tag failed: %v: %s", err, out)
+	}
+}
+
+func TestHighestGitTag(t *testing.T) {
+	dir := initGitRepo(t)
+	commitAndTag(t, dir, "v1.0.0")
+	commitAndTag(t, dir, "v1.2.0")
+	commitAndTag(t, dir, "v1.1.0")
+
+	tag, version, ok, err := highestGitTag(dir)
+	if err != nil {
+		t.Fatalf("highestGitTag returned error: %v", err)
+	}
+	if !ok {
+		t.Fatal("highestGitTag found no tag")
+	}
+	if tag != "v1.2.0" {
+		t.Errorf("highestGitTag tag = %q, want %q", tag, "v1.2.0")
+	}
+	if version.String() != "1.2.0" {
+		t.Errorf("highestGitTag version = %q, want %q", version.String(), "1.2.0")
+	}
+}
+
+func TestHighestGitTagNoneFound(t *testing.T) {
+	dir := initGitRepo(t)
+
+	_, _, ok, err := highestGitTag(dir)
+	if err != nil {
+		t.Fatalf("highestGitTag returned error: %v", err)
+	}
+	if ok {
+		t.Error("highestGitTag reported a tag when none exists")
+	}
+}
+
+func TestIsHeadAtTag(t *testing.T) {
+	dir := initGitRepo(t)
+	commitAndTag(t, dir, "v1.0.0")
+
+	atTag, err := isHeadAtTag(dir, "v1.0.0")
+	if err != nil {
+		t.Fatalf("isHeadAtTag returned error: %v", err)
+	}
+	if !atTag {
+		t.Error("isHeadAtTag = false, want true at the tagged commit")
+	}
+
+	cmd := exec.Command("git", "commit", "--allow-empty", "-q", "-m", "ahead")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, out)
+	}
+
+	atTag, err = isHeadAtTag(dir, "v1.0.0")
+	if err != nil {
+		t.Fatalf("isHeadAtTag returned error: %v", err)
+	}
+	if atTag {
+		t.Error("isHeadAtTag = true, want false once HEAD has moved past the tag")
+	}
+}
+
+func TestHeadCommitAndPseudoVersion(t *testing.T) {
+	dir := initGitRepo(t)
+	commitAndTag(t, dir, "v1.0.0")
+
+	cmd := exec.Command("git", "commit", "--allow-empty", "-q", "-m", "ahead")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, out)
+	}
+
+	sha, committed, err := headCommit(dir)
+	if err != nil {
+		t.Fatalf("headCommit returned error: %v", err)
+	}
+	if len(sha) != 40 {
+		t.Errorf("headCommit sha = %q, want a 40-character SHA", sha)
+	}
+	if committed.IsZero() {
+		t.Error("headCommit returned a zero committer date")
+	}
+
+	tagged, err := parseSemVer("1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pseudo := pseudoVersion(tagged, committed, sha)
+	wantPrefix := "v1.0.1-0." + committed.UTC().Format("20060102150405") + "-" + sha[:12]
+	if pseudo != wantPrefix {
+		t.Errorf("pseudoVersion = %q, want %q", pseudo, wantPrefix)
+	}
+}
+
+func TestCreateGitTag(t *testing.T) {
+	dir := initGitRepo(t)
+
+	if err := createGitTag(dir, "1.2.3"); err != nil {
+		t.Fatalf("createGitTag returned error: %v", err)
+	}
+
+	_, version, ok, err := highestGitTag(dir)
+	if err != nil {
+		t.Fatalf("highestGitTag returned error: %v", err)
+	}
+	if !ok || version.String() != "1.2.3" {
+		t.Errorf("expected tag v1.2.3 to be discoverable, got ok=%v version=%q", ok, version.String())
+	}
+}
+
+func TestGitToolVersion(t *testing.T) {
+	dir := initGitRepo(t)
+	commitAndTag(t, dir, "v1.0.0")
+
+	version, err := gitToolVersion(dir)
+	if err != nil {
+		t.Fatalf("gitToolVersion returned error: %v", err)
+	}
+	if version != "v1.0.0" {
+		t.Errorf("gitToolVersion at tag = %q, want %q", version, "v1.0.0")
+	}
+
+	cmd := exec.Command("git", "commit", "--allow-empty", "-q", "-m", "ahead")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, out)
+	}
+
+	version, err = gitToolVersion(dir)
+	if err != nil {
+		t.Fatalf("gitToolVersion returned error: %v", err)
+	}
+	if !strings.HasPrefix(version, "v1.0.1-0.") {
+		t.Errorf("gitToolVersion ahead of tag = %q, want v-prefixed pseudo-version", version)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// filePrefix is the --source value prefix selecting the plain-text VERSION
+// file source/sink, e.g. "file:VERSION".
+const filePrefix = "file:"
+
+// splitSource parses the --source flag into a kind ("go" or "file") and,
+// for "file", the configured file path.
+func splitSource(source string) (kind string, path string) {
+	if strings.HasPrefix(source, filePrefix) {
+		return "file", strings.TrimPrefix(source, filePrefix)
+	}
+
+	return "go", ""
+}
+
+// getCurrentVersionFromFile reads the first line of a plain-text version
+// file (e.g. VERSION), the format used by projects that want their version
+// readable by non-Go tooling (Dockerfiles, Helm charts, release scripts)
+// without parsing Go source.
+func getCurrentVersionFromFile(path string, lazyInit bool) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if lazyInit && os.IsNotExist(err) {
+			seed := seedVersion(filepath.Dir(path))
+			if *verbose {
+				fmt.Printf("Version file not found at [%s], initializing version to [%s]\n", path, seed)
+			}
+			return seed, nil
+		}
+		return "", err
+	}
+
+	line := strings.SplitN(string(data), "\n", 2)[0]
+	version := strings.TrimSpace(line)
+	if version == "" {
+		return "", fmt.Errorf("Version file [%s] is empty", path)
+	}
+
+	return version, nil
+}
+
+// writeVersionFile writes version, followed by a trailing newline, back to
+// the plain-text version file at path, creating it if necessary.
+func writeVersionFile(path string, version string) error {
+	return writeFileAtomically(path, []byte(version+"\n"), 0644)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitSource(t *testing.T) {
+	tests := []struct {
+		source   string
+		wantKind string
+		wantPath string
+	}{
+		{"go", "go", ""},
+		{"file:VERSION", "file", "VERSION"},
+		{"file:sub/VERSION", "file", "sub/VERSION"},
+	}
+
+	for _, test := range tests {
+		kind, path := splitSource(test.source)
+		if kind != test.wantKind || path != test.wantPath {
+			t.Errorf("splitSource(%q) = (%q, %q), want (%q, %q)", test.source, kind, path, test.wantKind, test.wantPath)
+		}
+	}
+}
+
+func TestGetCurrentVersionFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "VERSION")
+	if err := ioutil.WriteFile(path, []byte("1.2.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := getCurrentVersionFromFile(path, false)
+	if err != nil {
+		t.Fatalf("getCurrentVersionFromFile returned error: %v", err)
+	}
+	if version != "1.2.3" {
+		t.Errorf("getCurrentVersionFromFile = %q, want %q", version, "1.2.3")
+	}
+}
+
+func TestGetCurrentVersionFromFileEmpty(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "VERSION")
+	if err := ioutil.WriteFile(path, []byte("\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := getCurrentVersionFromFile(path, false); err == nil {
+		t.Error("getCurrentVersionFromFile on an empty file expected error, got none")
+	}
+}
+
+func TestGetCurrentVersionFromFileLazyInit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "VERSION")
+
+	version, err := getCurrentVersionFromFile(path, true)
+	if err != nil {
+		t.Fatalf("getCurrentVersionFromFile returned error: %v", err)
+	}
+	if version != "1.0.0" {
+		t.Errorf("getCurrentVersionFromFile lazy-init seed = %q, want %q", version, "1.0.0")
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		t.Error("getCurrentVersionFromFile lazy-init should not create the version file itself")
+	}
+}
+
+func TestGetCurrentVersionFromFileMissingNoLazyInit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "VERSION")
+
+	if _, err := getCurrentVersionFromFile(path, false); err == nil {
+		t.Error("getCurrentVersionFromFile expected error for a missing file without --init")
+	}
+}
+
+func TestWriteVersionFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "VERSION")
+
+	if err := writeVersionFile(path, "2.0.0"); err != nil {
+		t.Fatalf("writeVersionFile returned error: %v", err)
+	}
+
+	version, err := getCurrentVersionFromFile(path, false)
+	if err != nil {
+		t.Fatalf("getCurrentVersionFromFile returned error: %v", err)
+	}
+	if version != "2.0.0" {
+		t.Errorf("round-tripped version = %q, want %q", version, "2.0.0")
+	}
+}
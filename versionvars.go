@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// reservedVariableNames are the identifiers giddyup can derive from the
+// current version, commit and build in addition to the version string
+// itself.
+var reservedVariableNames = map[string]bool{
+	"MAJOR":      true,
+	"MINOR":      true,
+	"PATCH":      true,
+	"PRERELEASE": true,
+	"BUILD":      true,
+	"COMMIT":     true,
+	"BUILD_DATE": true,
+}
+
+// versionVariable is one --variable declaration: the identifier to emit and
+// whether it's a const (default, compile-time only) or a var (overridable
+// at link time via `-ldflags "-X main.NAME=value"`).
+type versionVariable struct {
+	Name string
+	Kind string // "const" or "var"
+}
+
+// parseVariableFlags parses the repeated --variable name[:kind] flag values
+// into declarations. kind defaults to "const" when omitted.
+func parseVariableFlags(raw []string) ([]versionVariable, error) {
+	if len(raw) == 0 {
+		return []versionVariable{{Name: "VERSION", Kind: "const"}}, nil
+	}
+
+	variables := make([]versionVariable, 0, len(raw))
+	for _, entry := range raw {
+		name := entry
+		kind := "const"
+		if idx := strings.Index(entry, ":"); idx >= 0 {
+			name = entry[:idx]
+			kind = entry[idx+1:]
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("Invalid --variable [%s]: name must not be empty", entry)
+		}
+		if kind != "const" && kind != "var" {
+			return nil, fmt.Errorf("Invalid --variable [%s]: kind must be [const] or [var], was [%s]", entry, kind)
+		}
+
+		variables = append(variables, versionVariable{Name: name, Kind: kind})
+	}
+
+	return variables, nil
+}
+
+// derivedValues computes the string value of every identifier giddyup can
+// derive from version and commit, leaving BUILD_DATE blank for -ldflags to
+// stamp at actual build time.
+func derivedValues(version semVer, versionStr string, commit string) map[string]string {
+	return map[string]string{
+		"VERSION":    versionStr,
+		"MAJOR":      strconv.Itoa(version.Major),
+		"MINOR":      strconv.Itoa(version.Minor),
+		"PATCH":      strconv.Itoa(version.Patch),
+		"PRERELEASE": strings.Join(version.Prerelease, "."),
+		"BUILD":      strings.Join(version.Build, "."),
+		"COMMIT":     commit,
+		"BUILD_DATE": "",
+	}
+}
+
+// valueForVariable returns the value to emit for a declared variable: its
+// derived value when its name is one of the reserved identifiers, or the
+// plain version string otherwise (preserving the original behavior of
+// --variable simply renaming the constant that holds the version).
+func valueForVariable(name string, values map[string]string) string {
+	if reservedVariableNames[name] || name == "VERSION" {
+		return values[name]
+	}
+
+	return values["VERSION"]
+}